@@ -0,0 +1,79 @@
+package ctxmux
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// CORSConfig configures the headers written for cross-origin requests by
+// MuxCORS.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	MaxAge           int
+	AllowCredentials bool
+}
+
+// MuxCORS adds the configured CORS headers to every response, and
+// enables httprouter's automatic OPTIONS handling so that preflight
+// requests receive a response even for paths with no Handler of their
+// own. A Handler configured with MuxOptionsHandler runs instead of the
+// default empty preflight response, whichever of the two options is
+// applied first.
+func MuxCORS(c CORSConfig) MuxOption {
+	return func(m *Mux) error {
+		m.Use(corsMiddleware(c))
+		m.r.HandleOPTIONS = true
+		if m.r.GlobalOPTIONS == nil {
+			m.r.GlobalOPTIONS = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeCORSHeaders(w, r, c)
+				w.WriteHeader(http.StatusNoContent)
+			})
+		}
+		return nil
+	}
+}
+
+func corsMiddleware(c CORSConfig) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			writeCORSHeaders(w, r, c)
+			return next(ctx, w, r)
+		}
+	}
+}
+
+func writeCORSHeaders(w http.ResponseWriter, r *http.Request, c CORSConfig) {
+	origin := r.Header.Get("Origin")
+	if origin == "" || !originAllowed(origin, c.AllowedOrigins) {
+		return
+	}
+
+	h := w.Header()
+	h.Set("Access-Control-Allow-Origin", origin)
+	if c.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(c.AllowedMethods) > 0 {
+		h.Set("Access-Control-Allow-Methods", strings.Join(c.AllowedMethods, ", "))
+	}
+	if len(c.AllowedHeaders) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(c.AllowedHeaders, ", "))
+	}
+	if c.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(c.MaxAge))
+	}
+}
+
+func originAllowed(origin string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == "*" || a == origin {
+			return true
+		}
+	}
+	return false
+}
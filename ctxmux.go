@@ -12,7 +12,11 @@
 package ctxmux
 
 import (
+	"net"
 	"net/http"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
 
 	"github.com/julienschmidt/httprouter"
 	"golang.org/x/net/context"
@@ -54,44 +58,102 @@ type Handler func(ctx context.Context, w http.ResponseWriter, r *http.Request) e
 
 // Mux provides shared context initialization and error handling.
 type Mux struct {
-	contextMaker func(*http.Request) (context.Context, error)
-	errorHandler ErrorHandler
-	panicHandler PanicHandler
-	r            httprouter.Router
+	contextMaker          func(*http.Request) (context.Context, error)
+	errorHandler          ErrorHandler
+	panicHandler          PanicHandler
+	panicHandlerWithStack PanicHandlerWithStack
+	recovery              MuxRecoveryOptions
+	middleware            []Middleware
+	routes                []RouteInfo
+	baseContextFunc       func(net.Listener) context.Context
+	rootContext           context.Context
+	inflight              int64
+	shuttingDown          int32
+	drained               chan struct{}
+	drainedOnce           sync.Once
+	r                     httprouter.Router
+}
+
+// addRoute records a registration for later retrieval via Routes/Walk.
+func (m *Mux) addRoute(method, path string, handler Handler) {
+	m.routes = append(m.routes, RouteInfo{
+		Method:  method,
+		Path:    path,
+		Handler: handler,
+	})
+}
+
+// route records original as the route's Handler for Routes/Walk, and
+// arranges for serving to actually handle the request. original and
+// serving differ when the caller applies its own wrapping, e.g. Group
+// middleware, that shouldn't be visible to RouteInfo.Handler.
+func (m *Mux) route(method, path string, original, serving Handler) {
+	m.addRoute(method, path, original)
+	m.r.Handle(method, path, m.wrap(serving))
+}
+
+func (m *Mux) rootCtx() context.Context {
+	if m.rootContext != nil {
+		return m.rootContext
+	}
+	return context.Background()
 }
 
 func (m *Mux) makeContext(r *http.Request) (context.Context, error) {
 	if m.contextMaker != nil {
 		ctx, err := m.contextMaker(r)
 		if err != nil {
-			return context.Background(), err
+			return m.rootCtx(), err
 		}
 		return ctx, nil
 	}
-	return context.Background(), nil
+	return m.rootCtx(), nil
 }
 
 func (m *Mux) wrap(handler Handler) httprouter.Handle {
+	handler = chain(handler, m.middleware)
 	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		// Increment before checking shuttingDown, not after, so a
+		// Shutdown that runs concurrently either observes this request
+		// in the in-flight count (and waits for it) or has already set
+		// the flag in time for this request to see it below; the two
+		// can't interleave such that Shutdown misses it. See
+		// requestDone for the other half of the handshake.
+		atomic.AddInt64(&m.inflight, 1)
+		if atomic.LoadInt32(&m.shuttingDown) != 0 {
+			m.requestDone()
+			m.handleError(m.rootCtx(), w, r, ErrShuttingDown)
+			return
+		}
+		defer m.requestDone()
+
 		var ctx context.Context // so the panicHandler can get to it
 
-		if m.panicHandler != nil {
-			defer func() {
-				if v := recover(); v != nil {
-					m.panicHandler(ctx, w, r, v)
-				}
-			}()
-		}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			switch {
+			case m.panicHandlerWithStack != nil:
+				m.panicHandlerWithStack(ctx, w, r, v, debug.Stack())
+			case m.panicHandler != nil:
+				m.panicHandler(ctx, w, r, v)
+			default:
+				m.defaultPanicHandler(ctx, w, r, v, debug.Stack())
+			}
+		}()
 
 		ctx, err := m.makeContext(r)
 		if err != nil {
-			m.errorHandler(ctx, w, r, err)
+			m.handleError(ctx, w, r, err)
 			return
 		}
 		ctx = WithParams(ctx, p)
+		ctx = withRequest(ctx, r)
 
 		if err := handler(ctx, w, r); err != nil {
-			m.errorHandler(ctx, w, r, err)
+			m.handleError(ctx, w, r, err)
 			return
 		}
 	}
@@ -99,37 +161,37 @@ func (m *Mux) wrap(handler Handler) httprouter.Handle {
 
 // Handler by method and path.
 func (m *Mux) Handler(method, path string, handler Handler) {
-	m.r.Handle(method, path, m.wrap(handler))
+	m.route(method, path, handler, handler)
 }
 
 // HEAD methods at path.
 func (m *Mux) HEAD(path string, handler Handler) {
-	m.r.HEAD(path, m.wrap(handler))
+	m.route("HEAD", path, handler, handler)
 }
 
 // GET methods at path.
 func (m *Mux) GET(path string, handler Handler) {
-	m.r.GET(path, m.wrap(handler))
+	m.route("GET", path, handler, handler)
 }
 
 // POST methods at path.
 func (m *Mux) POST(path string, handler Handler) {
-	m.r.POST(path, m.wrap(handler))
+	m.route("POST", path, handler, handler)
 }
 
 // PUT methods at path.
 func (m *Mux) PUT(path string, handler Handler) {
-	m.r.PUT(path, m.wrap(handler))
+	m.route("PUT", path, handler, handler)
 }
 
 // DELETE methods at path.
 func (m *Mux) DELETE(path string, handler Handler) {
-	m.r.DELETE(path, m.wrap(handler))
+	m.route("DELETE", path, handler, handler)
 }
 
 // PATCH methods at path.
 func (m *Mux) PATCH(path string, handler Handler) {
-	m.r.PATCH(path, m.wrap(handler))
+	m.route("PATCH", path, handler, handler)
 }
 
 // ServeHTTP allows Mux to be used as a http.Handler.
@@ -153,9 +215,10 @@ func MuxContextMaker(f func(*http.Request) (context.Context, error)) MuxOption {
 // ErrorHandler is invoked with errors returned by handler functions.
 type ErrorHandler func(context.Context, http.ResponseWriter, *http.Request, error)
 
-// MuxErrorHandler configures the ErrorHandler for the Mux. If one isn't set,
-// the default behaviour is to log it and send a static error message of
-// "internal server error".
+// MuxErrorHandler configures the ErrorHandler for the Mux. If one isn't
+// set, the default logs errors that don't implement HTTPError via the
+// configured Logger (see MuxLogger and MuxRecovery) and renders the
+// response with DefaultErrorHandler.
 func MuxErrorHandler(handler ErrorHandler) MuxOption {
 	return func(m *Mux) error {
 		m.errorHandler = handler
@@ -163,13 +226,24 @@ func MuxErrorHandler(handler ErrorHandler) MuxOption {
 	}
 }
 
+// handleError dispatches to the configured ErrorHandler, falling back to
+// defaultErrorHandler when none is set.
+func (m *Mux) handleError(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	if m.errorHandler != nil {
+		m.errorHandler(ctx, w, r, err)
+		return
+	}
+	m.defaultErrorHandler(ctx, w, r, err)
+}
+
 // PanicHandler is invoked with the panics that occur during context creation
 // or while the handler is running.
 type PanicHandler func(context.Context, http.ResponseWriter, *http.Request, interface{})
 
-// MuxPanicHandler configures the panic handler for the Mux. If one is not
-// configured, the default behavior is what the net/http package does; which is
-// to print a trace and ignore it.
+// MuxPanicHandler configures the panic handler for the Mux. If neither this
+// nor MuxPanicHandlerWithStack is configured, the default behavior is to
+// recover the panic, log it via the configured Logger (see MuxLogger and
+// MuxRecovery) and send the MuxRecovery status code, an HTTP 500 by default.
 func MuxPanicHandler(handler PanicHandler) MuxOption {
 	return func(m *Mux) error {
 		m.panicHandler = handler
@@ -182,9 +256,9 @@ func MuxPanicHandler(handler PanicHandler) MuxOption {
 func MuxNotFoundHandler(handler Handler) MuxOption {
 	return func(m *Mux) error {
 		h := m.wrap(handler)
-		m.r.NotFound = func(w http.ResponseWriter, r *http.Request) {
+		m.r.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			h(w, r, nil)
-		}
+		})
 		return nil
 	}
 }
@@ -199,6 +273,7 @@ func MuxRedirectTrailingSlash(m *Mux) error {
 // New creates a new Mux and configures it with the given options.
 func New(options ...MuxOption) (*Mux, error) {
 	var m Mux
+	m.drained = make(chan struct{})
 	for _, o := range options {
 		if err := o(&m); err != nil {
 			return nil, err
@@ -0,0 +1,132 @@
+package ctxmux
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// HTTPError is implemented by errors that want control over how
+// DefaultErrorHandler renders their response: the status code sent to
+// the client, the message shown to them, and any extra headers.
+type HTTPError interface {
+	StatusCode() int
+	PublicMessage() string
+	Headers() http.Header
+}
+
+type httpError struct {
+	statusCode    int
+	publicMessage string
+	headers       http.Header
+	cause         error
+}
+
+func (e *httpError) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %s", e.publicMessage, e.cause)
+	}
+	return e.publicMessage
+}
+
+func (e *httpError) StatusCode() int       { return e.statusCode }
+func (e *httpError) PublicMessage() string { return e.publicMessage }
+func (e *httpError) Headers() http.Header  { return e.headers }
+func (e *httpError) Cause() error          { return e.cause }
+
+// Error constructs an error carrying an HTTP status code and a message
+// safe to show to the client, implementing HTTPError so
+// DefaultErrorHandler renders it consistently. cause, if non-nil, is
+// included in Error() for logging but is never sent to the client.
+func Error(status int, msg string, cause error) error {
+	return &httpError{statusCode: status, publicMessage: msg, cause: cause}
+}
+
+// WrapError wraps err as an HTTPError with the given status code, using
+// err's own message as the public message sent to the client.
+func WrapError(err error, status int) error {
+	if err == nil {
+		return nil
+	}
+	return &httpError{statusCode: status, publicMessage: err.Error(), cause: err}
+}
+
+// defaultErrorHandler is used when MuxErrorHandler isn't configured. err
+// that don't implement HTTPError are opaque, unexpected errors, so they're
+// logged via the configured Logger (see MuxLogger and MuxRecovery) before
+// DefaultErrorHandler renders the response; an HTTPError is assumed to
+// already be accounted for by its returning code and isn't logged here.
+func (m *Mux) defaultErrorHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	if _, ok := err.(HTTPError); !ok {
+		logger := m.recovery.Logger
+		if logger == nil {
+			logger = defaultLogger
+		}
+		logger.Println(fmt.Sprintf("ctxmux: unhandled error: %v", err))
+	}
+	DefaultErrorHandler(ctx, w, r, err)
+}
+
+// DefaultErrorHandler is the rendering used by the default ErrorHandler.
+// If err implements HTTPError, its StatusCode, PublicMessage and Headers
+// are used to build the response; otherwise it's treated as an opaque
+// 500 with a generic message. The response body is content-negotiated as
+// JSON, HTML or plain text based on the request's Accept header.
+func DefaultErrorHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	msg := "internal server error"
+
+	if he, ok := err.(HTTPError); ok {
+		status = he.StatusCode()
+		msg = he.PublicMessage()
+		for k, vs := range he.Headers() {
+			for _, v := range vs {
+				w.Header().Add(k, v)
+			}
+		}
+	}
+
+	switch acceptedContentType(r) {
+	case contentTypeJSON:
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(struct {
+			Error string `json:"error"`
+		}{Error: msg})
+	case contentTypeHTML:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, "<html><body><h1>%d %s</h1><p>%s</p></body></html>",
+			status, http.StatusText(status), html.EscapeString(msg))
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprintln(w, msg)
+	}
+}
+
+type contentType int
+
+const (
+	contentTypeText contentType = iota
+	contentTypeJSON
+	contentTypeHTML
+)
+
+// acceptedContentType picks a response content type based on the
+// request's Accept header, defaulting to plain text.
+func acceptedContentType(r *http.Request) contentType {
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "application/json"):
+		return contentTypeJSON
+	case strings.Contains(accept, "text/html"):
+		return contentTypeHTML
+	default:
+		return contentTypeText
+	}
+}
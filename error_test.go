@@ -0,0 +1,80 @@
+package ctxmux_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/daaku/ctxmux"
+	"github.com/facebookgo/ensure"
+	"golang.org/x/net/context"
+)
+
+func TestDefaultErrorHandlerPlainText(t *testing.T) {
+	mux, err := ctxmux.New()
+	ensure.Nil(t, err)
+	mux.GET("/", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return ctxmux.Error(http.StatusNotFound, "no such widget", errors.New("db: no rows"))
+	})
+	hw := httptest.NewRecorder()
+	hr := &http.Request{Method: "GET", URL: &url.URL{Path: "/"}}
+	mux.ServeHTTP(hw, hr)
+	ensure.DeepEqual(t, hw.Code, http.StatusNotFound)
+	ensure.DeepEqual(t, hw.Body.String(), "no such widget\n")
+}
+
+func TestDefaultErrorHandlerLogsOpaqueError(t *testing.T) {
+	var logger logLines
+	mux, err := ctxmux.New(ctxmux.MuxLogger(&logger))
+	ensure.Nil(t, err)
+	mux.GET("/", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return errors.New("boom")
+	})
+	hw := httptest.NewRecorder()
+	hr := &http.Request{Method: "GET", URL: &url.URL{Path: "/"}}
+	mux.ServeHTTP(hw, hr)
+	ensure.DeepEqual(t, hw.Code, http.StatusInternalServerError)
+	ensure.True(t, len(logger.lines) == 1)
+}
+
+func TestDefaultErrorHandlerDoesNotLogHTTPError(t *testing.T) {
+	var logger logLines
+	mux, err := ctxmux.New(ctxmux.MuxLogger(&logger))
+	ensure.Nil(t, err)
+	mux.GET("/", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return ctxmux.Error(http.StatusNotFound, "no such widget", nil)
+	})
+	hw := httptest.NewRecorder()
+	hr := &http.Request{Method: "GET", URL: &url.URL{Path: "/"}}
+	mux.ServeHTTP(hw, hr)
+	ensure.DeepEqual(t, hw.Code, http.StatusNotFound)
+	ensure.True(t, len(logger.lines) == 0)
+}
+
+func TestDefaultErrorHandlerJSON(t *testing.T) {
+	mux, err := ctxmux.New()
+	ensure.Nil(t, err)
+	mux.GET("/", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return errors.New("boom")
+	})
+	hw := httptest.NewRecorder()
+	hr := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/"},
+		Header: http.Header{"Accept": []string{"application/json"}},
+	}
+	mux.ServeHTTP(hw, hr)
+	ensure.DeepEqual(t, hw.Code, http.StatusInternalServerError)
+	ensure.DeepEqual(t, hw.Body.String(), "{\"error\":\"internal server error\"}\n")
+}
+
+func TestWrapError(t *testing.T) {
+	cause := errors.New("boom")
+	err := ctxmux.WrapError(cause, http.StatusBadGateway)
+	he, ok := err.(ctxmux.HTTPError)
+	ensure.True(t, ok)
+	ensure.DeepEqual(t, he.StatusCode(), http.StatusBadGateway)
+	ensure.DeepEqual(t, he.PublicMessage(), "boom")
+}
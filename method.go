@@ -0,0 +1,46 @@
+package ctxmux
+
+import "net/http"
+
+// MuxMethodNotAllowedHandler configures a Handler invoked when a request
+// matches a registered path but not its method, wiring it through the
+// same makeContext/panic/error pipeline as regular routes. It enables
+// httprouter's HandleMethodNotAllowed, which is what computes the Allow
+// header for the path.
+func MuxMethodNotAllowedHandler(handler Handler) MuxOption {
+	return func(m *Mux) error {
+		m.r.HandleMethodNotAllowed = true
+		m.r.MethodNotAllowed = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Built per-request, not captured at MuxOption-application
+			// time, so Use/MuxCORS calls made on the returned *Mux after
+			// New() still apply here.
+			m.wrap(handler)(w, r, nil)
+		})
+		return nil
+	}
+}
+
+// MuxOptionsHandler configures a Handler invoked for OPTIONS requests,
+// wiring it through the same makeContext/panic/error pipeline as regular
+// routes. It enables httprouter's HandleOPTIONS.
+func MuxOptionsHandler(handler Handler) MuxOption {
+	return func(m *Mux) error {
+		m.r.HandleOPTIONS = true
+		m.r.GlobalOPTIONS = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// See MuxMethodNotAllowedHandler for why this isn't wrapped
+			// eagerly.
+			m.wrap(handler)(w, r, nil)
+		})
+		return nil
+	}
+}
+
+// MuxAutoOPTIONS enables httprouter's automatic OPTIONS responses, which
+// list the methods allowed for the request path in the Allow header,
+// without requiring a Handler of your own.
+func MuxAutoOPTIONS() MuxOption {
+	return func(m *Mux) error {
+		m.r.HandleOPTIONS = true
+		return nil
+	}
+}
@@ -0,0 +1,101 @@
+package ctxmux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/daaku/ctxmux"
+	"github.com/facebookgo/ensure"
+	"golang.org/x/net/context"
+)
+
+func TestMuxMethodNotAllowedHandler(t *testing.T) {
+	var called bool
+	mux, err := ctxmux.New(
+		ctxmux.MuxMethodNotAllowedHandler(
+			func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				called = true
+				return nil
+			}),
+	)
+	ensure.Nil(t, err)
+	mux.GET("/foo", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	hw := httptest.NewRecorder()
+	hr := &http.Request{Method: "POST", URL: &url.URL{Path: "/foo"}}
+	mux.ServeHTTP(hw, hr)
+	ensure.True(t, called)
+}
+
+func TestMuxMethodNotAllowedHandlerSeesUseAfterNew(t *testing.T) {
+	var mwRan bool
+	mux, err := ctxmux.New(
+		ctxmux.MuxMethodNotAllowedHandler(
+			func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				return nil
+			}),
+	)
+	ensure.Nil(t, err)
+	mux.Use(func(next ctxmux.Handler) ctxmux.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			mwRan = true
+			return next(ctx, w, r)
+		}
+	})
+	mux.GET("/foo", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	hw := httptest.NewRecorder()
+	hr := &http.Request{Method: "POST", URL: &url.URL{Path: "/foo"}}
+	mux.ServeHTTP(hw, hr)
+	ensure.True(t, mwRan)
+}
+
+func TestMuxOptionsHandlerSeesCORS(t *testing.T) {
+	mux, err := ctxmux.New(
+		ctxmux.MuxOptionsHandler(
+			func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+				return nil
+			}),
+		ctxmux.MuxCORS(ctxmux.CORSConfig{
+			AllowedOrigins: []string{"https://example.com"},
+			AllowedMethods: []string{"GET", "OPTIONS"},
+		}),
+	)
+	ensure.Nil(t, err)
+	mux.GET("/foo", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	hw := httptest.NewRecorder()
+	hr := &http.Request{
+		Method: "OPTIONS",
+		URL:    &url.URL{Path: "/foo"},
+		Header: http.Header{"Origin": []string{"https://example.com"}},
+	}
+	mux.ServeHTTP(hw, hr)
+	ensure.DeepEqual(t, hw.Header().Get("Access-Control-Allow-Origin"), "https://example.com")
+}
+
+func TestMuxCORSHeaders(t *testing.T) {
+	mux, err := ctxmux.New(
+		ctxmux.MuxCORS(ctxmux.CORSConfig{
+			AllowedOrigins: []string{"https://example.com"},
+			AllowedMethods: []string{"GET", "POST"},
+		}),
+	)
+	ensure.Nil(t, err)
+	mux.GET("/", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	hw := httptest.NewRecorder()
+	hr := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/"},
+		Header: http.Header{"Origin": []string{"https://example.com"}},
+	}
+	mux.ServeHTTP(hw, hr)
+	ensure.DeepEqual(t, hw.Header().Get("Access-Control-Allow-Origin"), "https://example.com")
+}
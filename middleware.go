@@ -0,0 +1,131 @@
+package ctxmux
+
+// Middleware wraps a Handler to add cross-cutting behavior such as
+// logging, authentication, request IDs or metrics. When composed, the
+// first Middleware passed to Use or Group runs outermost, closest to the
+// panic/error boundary in wrap, and the last runs closest to the
+// handler.
+type Middleware func(Handler) Handler
+
+// chain composes mws around handler, with mws[0] running outermost.
+func chain(handler Handler, mws []Middleware) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	return handler
+}
+
+// Use appends mws to the Mux's global middleware chain. The chain is
+// applied to every Handler registered afterwards, so Use should be
+// called before registering routes. Because the chain runs inside wrap,
+// a middleware returning an error flows through the configured
+// ErrorHandler just like a regular handler's error would, and the
+// context built by contextMaker/WithParams is visible to every
+// middleware.
+func (m *Mux) Use(mws ...Middleware) {
+	m.middleware = append(m.middleware, mws...)
+}
+
+// HandlerWith is like Handler but additionally applies mws, which run
+// after the Mux's global middleware chain and before handler.
+func (m *Mux) HandlerWith(method, path string, mws []Middleware, handler Handler) {
+	m.route(method, path, handler, chain(handler, mws))
+}
+
+// HEADWith is like HEAD but additionally applies mws.
+func (m *Mux) HEADWith(path string, mws []Middleware, handler Handler) {
+	m.route("HEAD", path, handler, chain(handler, mws))
+}
+
+// GETWith is like GET but additionally applies mws.
+func (m *Mux) GETWith(path string, mws []Middleware, handler Handler) {
+	m.route("GET", path, handler, chain(handler, mws))
+}
+
+// POSTWith is like POST but additionally applies mws.
+func (m *Mux) POSTWith(path string, mws []Middleware, handler Handler) {
+	m.route("POST", path, handler, chain(handler, mws))
+}
+
+// PUTWith is like PUT but additionally applies mws.
+func (m *Mux) PUTWith(path string, mws []Middleware, handler Handler) {
+	m.route("PUT", path, handler, chain(handler, mws))
+}
+
+// DELETEWith is like DELETE but additionally applies mws.
+func (m *Mux) DELETEWith(path string, mws []Middleware, handler Handler) {
+	m.route("DELETE", path, handler, chain(handler, mws))
+}
+
+// PATCHWith is like PATCH but additionally applies mws.
+func (m *Mux) PATCHWith(path string, mws []Middleware, handler Handler) {
+	m.route("PATCH", path, handler, chain(handler, mws))
+}
+
+// Group is a sub-router rooted at a path prefix that inherits its
+// parent's middleware chain and may extend it with its own. Routes
+// registered through a Group are registered on the underlying Mux with
+// the prefix prepended to the path.
+type Group struct {
+	mux        *Mux
+	prefix     string
+	middleware []Middleware
+}
+
+// Group returns a new Group rooted at path prefix, inheriting the Mux's
+// global middleware chain and extending it with mws.
+func (m *Mux) Group(prefix string, mws ...Middleware) *Group {
+	return &Group{mux: m, prefix: prefix, middleware: mws}
+}
+
+// Group returns a new Group nested under g, with prefix appended to g's
+// prefix and mws appended to g's middleware chain.
+func (g *Group) Group(prefix string, mws ...Middleware) *Group {
+	middleware := make([]Middleware, 0, len(g.middleware)+len(mws))
+	middleware = append(middleware, g.middleware...)
+	middleware = append(middleware, mws...)
+	return &Group{mux: g.mux, prefix: g.prefix + prefix, middleware: middleware}
+}
+
+// Handler registers handler for method and path, with the Group's prefix
+// prepended to path and the Group's middleware chain applied. The
+// Group's middleware is not visible to RouteInfo.Handler; see Routes.
+func (g *Group) Handler(method, path string, handler Handler) {
+	g.mux.route(method, g.prefix+path, handler, chain(handler, g.middleware))
+}
+
+// HEAD registers handler for path, with the Group's prefix and
+// middleware applied.
+func (g *Group) HEAD(path string, handler Handler) {
+	g.mux.route("HEAD", g.prefix+path, handler, chain(handler, g.middleware))
+}
+
+// GET registers handler for path, with the Group's prefix and
+// middleware applied.
+func (g *Group) GET(path string, handler Handler) {
+	g.mux.route("GET", g.prefix+path, handler, chain(handler, g.middleware))
+}
+
+// POST registers handler for path, with the Group's prefix and
+// middleware applied.
+func (g *Group) POST(path string, handler Handler) {
+	g.mux.route("POST", g.prefix+path, handler, chain(handler, g.middleware))
+}
+
+// PUT registers handler for path, with the Group's prefix and
+// middleware applied.
+func (g *Group) PUT(path string, handler Handler) {
+	g.mux.route("PUT", g.prefix+path, handler, chain(handler, g.middleware))
+}
+
+// DELETE registers handler for path, with the Group's prefix and
+// middleware applied.
+func (g *Group) DELETE(path string, handler Handler) {
+	g.mux.route("DELETE", g.prefix+path, handler, chain(handler, g.middleware))
+}
+
+// PATCH registers handler for path, with the Group's prefix and
+// middleware applied.
+func (g *Group) PATCH(path string, handler Handler) {
+	g.mux.route("PATCH", g.prefix+path, handler, chain(handler, g.middleware))
+}
@@ -0,0 +1,68 @@
+package ctxmux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/daaku/ctxmux"
+	"github.com/facebookgo/ensure"
+	"golang.org/x/net/context"
+)
+
+func marker(name string, calls *[]string) ctxmux.Middleware {
+	return func(h ctxmux.Handler) ctxmux.Handler {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			*calls = append(*calls, name)
+			return h(ctx, w, r)
+		}
+	}
+}
+
+func TestUseAppliesToHandlersInOrder(t *testing.T) {
+	var calls []string
+	mux, err := ctxmux.New()
+	ensure.Nil(t, err)
+	mux.Use(marker("one", &calls), marker("two", &calls))
+	mux.GET("/", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+	hw := httptest.NewRecorder()
+	hr := &http.Request{Method: "GET", URL: &url.URL{Path: "/"}}
+	mux.ServeHTTP(hw, hr)
+	ensure.DeepEqual(t, calls, []string{"one", "two", "handler"})
+}
+
+func TestGroupPrefixAndMiddleware(t *testing.T) {
+	var calls []string
+	mux, err := ctxmux.New()
+	ensure.Nil(t, err)
+	mux.Use(marker("global", &calls))
+	api := mux.Group("/api", marker("group", &calls))
+	api.GET("/widgets", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+	hw := httptest.NewRecorder()
+	hr := &http.Request{Method: "GET", URL: &url.URL{Path: "/api/widgets"}}
+	mux.ServeHTTP(hw, hr)
+	ensure.DeepEqual(t, calls, []string{"global", "group", "handler"})
+}
+
+func TestGETWithRouteMiddleware(t *testing.T) {
+	var calls []string
+	mux, err := ctxmux.New()
+	ensure.Nil(t, err)
+	mux.Use(marker("global", &calls))
+	mux.GETWith("/", []ctxmux.Middleware{marker("route", &calls)},
+		func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+			calls = append(calls, "handler")
+			return nil
+		})
+	hw := httptest.NewRecorder()
+	hr := &http.Request{Method: "GET", URL: &url.URL{Path: "/"}}
+	mux.ServeHTTP(hw, hr)
+	ensure.DeepEqual(t, calls, []string{"global", "route", "handler"})
+}
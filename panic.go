@@ -0,0 +1,88 @@
+package ctxmux
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"golang.org/x/net/context"
+)
+
+// Logger is the minimal logging interface used by the built-in panic
+// recovery. *log.Logger satisfies it, so the standard library's log
+// package can be used directly.
+type Logger interface {
+	Println(v ...interface{})
+}
+
+// defaultLogger is used when MuxRecoveryOptions.Logger is left unset.
+var defaultLogger Logger = log.New(os.Stderr, "", log.LstdFlags)
+
+// PanicHandlerWithStack is like PanicHandler but additionally receives
+// the stack trace captured at the point of the panic, as returned by
+// debug.Stack().
+type PanicHandlerWithStack func(ctx context.Context, w http.ResponseWriter, r *http.Request, v interface{}, stack []byte)
+
+// MuxRecoveryOptions configures the built-in panic recovery used when
+// neither a PanicHandler nor a PanicHandlerWithStack is configured.
+type MuxRecoveryOptions struct {
+	// PrintStack includes the captured stack trace in the logged message.
+	PrintStack bool
+
+	// Logger receives the panic, and optionally its stack, as a single
+	// formatted message. Defaults to a *log.Logger writing to os.Stderr.
+	Logger Logger
+
+	// StatusCode is sent to the client. Defaults to http.StatusInternalServerError.
+	StatusCode int
+}
+
+// MuxPanicHandlerWithStack is like MuxPanicHandler, except the configured
+// handler additionally receives the stack trace captured at the point of
+// the panic.
+func MuxPanicHandlerWithStack(handler PanicHandlerWithStack) MuxOption {
+	return func(m *Mux) error {
+		m.panicHandlerWithStack = handler
+		return nil
+	}
+}
+
+// MuxLogger configures the Logger used by the built-in panic recovery.
+// It sets only the Logger, leaving the other MuxRecoveryOptions as they
+// are; a MuxRecovery applied after it replaces all of them.
+func MuxLogger(l Logger) MuxOption {
+	return func(m *Mux) error {
+		m.recovery.Logger = l
+		return nil
+	}
+}
+
+// MuxRecovery configures the built-in panic recovery used when neither a
+// PanicHandler nor a PanicHandlerWithStack is configured.
+func MuxRecovery(o MuxRecoveryOptions) MuxOption {
+	return func(m *Mux) error {
+		m.recovery = o
+		return nil
+	}
+}
+
+// defaultPanicHandler logs v (and optionally stack) via the configured
+// Logger and sends the configured status code, or a 500 if unset.
+func (m *Mux) defaultPanicHandler(ctx context.Context, w http.ResponseWriter, r *http.Request, v interface{}, stack []byte) {
+	logger := m.recovery.Logger
+	if logger == nil {
+		logger = defaultLogger
+	}
+	if m.recovery.PrintStack {
+		logger.Println(fmt.Sprintf("ctxmux: panic recovered: %v\n%s", v, stack))
+	} else {
+		logger.Println(fmt.Sprintf("ctxmux: panic recovered: %v", v))
+	}
+
+	status := m.recovery.StatusCode
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	http.Error(w, http.StatusText(status), status)
+}
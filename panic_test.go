@@ -0,0 +1,56 @@
+package ctxmux_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/daaku/ctxmux"
+	"github.com/facebookgo/ensure"
+	"golang.org/x/net/context"
+)
+
+type logLines struct {
+	lines []string
+}
+
+func (l *logLines) Println(v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprint(v...))
+}
+
+func TestDefaultPanicRecovery(t *testing.T) {
+	var logger logLines
+	mux, err := ctxmux.New(ctxmux.MuxLogger(&logger))
+	ensure.Nil(t, err)
+	mux.GET("/", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		panic("boom")
+	})
+	hw := httptest.NewRecorder()
+	hr := &http.Request{Method: "GET", URL: &url.URL{Path: "/"}}
+	mux.ServeHTTP(hw, hr)
+	ensure.DeepEqual(t, hw.Code, http.StatusInternalServerError)
+	ensure.True(t, len(logger.lines) == 1)
+}
+
+func TestPanicHandlerWithStack(t *testing.T) {
+	var actualV interface{}
+	var actualStack []byte
+	mux, err := ctxmux.New(
+		ctxmux.MuxPanicHandlerWithStack(
+			func(ctx context.Context, w http.ResponseWriter, r *http.Request, v interface{}, stack []byte) {
+				actualV = v
+				actualStack = stack
+			}),
+	)
+	ensure.Nil(t, err)
+	mux.GET("/", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		panic("boom")
+	})
+	hw := httptest.NewRecorder()
+	hr := &http.Request{Method: "GET", URL: &url.URL{Path: "/"}}
+	mux.ServeHTTP(hw, hr)
+	ensure.DeepEqual(t, actualV, "boom")
+	ensure.True(t, len(actualStack) > 0)
+}
@@ -0,0 +1,145 @@
+package ctxmux
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"golang.org/x/net/context"
+)
+
+type contextRequestKeyT int
+
+var contextRequestKey = contextRequestKeyT(0)
+
+// withRequest returns a new context.Context instance with the request
+// included, so DecodeParams can get to its query string.
+func withRequest(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, contextRequestKey, r)
+}
+
+// contextRequest extracts the request from the context if possible.
+func contextRequest(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(contextRequestKey).(*http.Request)
+	return r
+}
+
+// ContextParamString returns the named path parameter and whether it was
+// present.
+func ContextParamString(ctx context.Context, name string) (string, bool) {
+	for _, p := range ContextParams(ctx) {
+		if p.Key == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ContextParamInt returns the named path parameter parsed as an int64.
+// It returns an error if the parameter is missing or isn't a valid
+// integer.
+func ContextParamInt(ctx context.Context, name string) (int64, error) {
+	v, ok := ContextParamString(ctx, name)
+	if !ok {
+		return 0, fmt.Errorf("ctxmux: no such param %q", name)
+	}
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("ctxmux: param %q is not an int: %s", name, v)
+	}
+	return i, nil
+}
+
+var uuidRe = regexp.MustCompile(
+	`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ContextParamUUID returns the named path parameter, validated to be a
+// canonical (8-4-4-4-12 hex) UUID string. It returns an error if the
+// parameter is missing or isn't a valid UUID.
+func ContextParamUUID(ctx context.Context, name string) (string, error) {
+	v, ok := ContextParamString(ctx, name)
+	if !ok {
+		return "", fmt.Errorf("ctxmux: no such param %q", name)
+	}
+	if !uuidRe.MatchString(v) {
+		return "", fmt.Errorf("ctxmux: param %q is not a UUID: %q", name, v)
+	}
+	return v, nil
+}
+
+// DecodeParams populates the fields of dst, which must be a pointer to a
+// struct, from the request's path parameters and query string. A field
+// tagged `param:"name"` is populated from the path parameter of that
+// name; a field tagged `query:"name"` is populated from the query
+// parameter of that name. Supported field kinds are string, the signed
+// and unsigned integer kinds, and bool. Missing parameters are left
+// untouched.
+func DecodeParams(ctx context.Context, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ctxmux: DecodeParams requires a pointer to a struct, got %T", dst)
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	var query url.Values
+	if r := contextRequest(ctx); r != nil {
+		query = r.URL.Query()
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		fv := v.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+		field := t.Field(i)
+
+		if name, ok := field.Tag.Lookup("param"); ok {
+			if val, ok := ContextParamString(ctx, name); ok {
+				if err := setField(fv, val); err != nil {
+					return fmt.Errorf("ctxmux: param %q: %s", name, err)
+				}
+			}
+		}
+
+		if name, ok := field.Tag.Lookup("query"); ok && query != nil {
+			if val := query.Get(name); val != "" {
+				if err := setField(fv, val); err != nil {
+					return fmt.Errorf("ctxmux: query %q: %s", name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func setField(fv reflect.Value, val string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(val)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetUint(u)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field kind %s", fv.Kind())
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package ctxmux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/daaku/ctxmux"
+	"github.com/facebookgo/ensure"
+	"github.com/julienschmidt/httprouter"
+	"golang.org/x/net/context"
+)
+
+func TestContextParamString(t *testing.T) {
+	ctx := ctxmux.WithParams(context.Background(), httprouter.Params{{Key: "id", Value: "42"}})
+	v, ok := ctxmux.ContextParamString(ctx, "id")
+	ensure.True(t, ok)
+	ensure.DeepEqual(t, v, "42")
+
+	_, ok = ctxmux.ContextParamString(ctx, "missing")
+	ensure.False(t, ok)
+}
+
+func TestContextParamInt(t *testing.T) {
+	ctx := ctxmux.WithParams(context.Background(), httprouter.Params{{Key: "id", Value: "42"}})
+	v, err := ctxmux.ContextParamInt(ctx, "id")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, v, int64(42))
+
+	ctx = ctxmux.WithParams(context.Background(), httprouter.Params{{Key: "id", Value: "nope"}})
+	_, err = ctxmux.ContextParamInt(ctx, "id")
+	ensure.NotNil(t, err)
+}
+
+func TestContextParamUUID(t *testing.T) {
+	const id = "550e8400-e29b-41d4-a716-446655440000"
+	ctx := ctxmux.WithParams(context.Background(), httprouter.Params{{Key: "id", Value: id}})
+	v, err := ctxmux.ContextParamUUID(ctx, "id")
+	ensure.Nil(t, err)
+	ensure.DeepEqual(t, v, id)
+
+	ctx = ctxmux.WithParams(context.Background(), httprouter.Params{{Key: "id", Value: "not-a-uuid"}})
+	_, err = ctxmux.ContextParamUUID(ctx, "id")
+	ensure.NotNil(t, err)
+}
+
+type decodeTarget struct {
+	ID     int64  `param:"id"`
+	Name   string `param:"name"`
+	Filter string `query:"filter"`
+}
+
+func TestDecodeParams(t *testing.T) {
+	var got decodeTarget
+	mux, err := ctxmux.New()
+	ensure.Nil(t, err)
+	mux.GET("/widgets/:id/:name", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return ctxmux.DecodeParams(ctx, &got)
+	})
+	hw := httptest.NewRecorder()
+	hr := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/widgets/42/gadget", RawQuery: "filter=active"},
+	}
+	mux.ServeHTTP(hw, hr)
+	ensure.DeepEqual(t, got, decodeTarget{ID: 42, Name: "gadget", Filter: "active"})
+}
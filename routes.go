@@ -0,0 +1,36 @@
+package ctxmux
+
+// RouteInfo describes a single registered route.
+type RouteInfo struct {
+	// Method is the HTTP method the route was registered for, e.g. "GET".
+	Method string
+
+	// Path is the route's path pattern, as passed to Handler/GET/... ,
+	// with the prefix prepended for routes registered through a Group.
+	Path string
+
+	// Handler is the original Handler as registered, before the
+	// context/panic/error wrapping applied in wrap. Tools can invoke it
+	// directly, e.g. in tests.
+	Handler Handler
+}
+
+// Routes returns every route registered on the Mux, in registration
+// order. This is useful for building tooling such as automatic OpenAPI
+// output, a /debug/routes endpoint, or test assertions.
+func (m *Mux) Routes() []RouteInfo {
+	routes := make([]RouteInfo, len(m.routes))
+	copy(routes, m.routes)
+	return routes
+}
+
+// Walk calls f for every registered route, in registration order,
+// stopping and returning the first error f returns.
+func (m *Mux) Walk(f func(RouteInfo) error) error {
+	for _, route := range m.routes {
+		if err := f(route); err != nil {
+			return err
+		}
+	}
+	return nil
+}
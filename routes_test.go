@@ -0,0 +1,58 @@
+package ctxmux_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/daaku/ctxmux"
+	"github.com/facebookgo/ensure"
+	"golang.org/x/net/context"
+)
+
+func TestRoutesRecordsRegistrations(t *testing.T) {
+	mux, err := ctxmux.New()
+	ensure.Nil(t, err)
+	noop := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error { return nil }
+	mux.GET("/foo", noop)
+	mux.POST("/foo", noop)
+	routes := mux.Routes()
+	ensure.DeepEqual(t, len(routes), 2)
+	ensure.DeepEqual(t, routes[0].Method, "GET")
+	ensure.DeepEqual(t, routes[0].Path, "/foo")
+	ensure.DeepEqual(t, routes[1].Method, "POST")
+}
+
+func TestRoutesHandlerExcludesGroupMiddleware(t *testing.T) {
+	var calls []string
+	mux, err := ctxmux.New()
+	ensure.Nil(t, err)
+	g := mux.Group("/api", marker("mw", &calls))
+	g.GET("/foo", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+
+	routes := mux.Routes()
+	ensure.DeepEqual(t, len(routes), 1)
+	ensure.DeepEqual(t, routes[0].Path, "/api/foo")
+
+	ensure.Nil(t, routes[0].Handler(context.Background(), nil, nil))
+	ensure.DeepEqual(t, calls, []string{"handler"})
+}
+
+func TestWalkStopsOnError(t *testing.T) {
+	mux, err := ctxmux.New()
+	ensure.Nil(t, err)
+	noop := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error { return nil }
+	mux.GET("/a", noop)
+	mux.GET("/b", noop)
+	givenErr := errors.New("stop")
+	var visited []string
+	walkErr := mux.Walk(func(r ctxmux.RouteInfo) error {
+		visited = append(visited, r.Path)
+		return givenErr
+	})
+	ensure.DeepEqual(t, walkErr, givenErr)
+	ensure.DeepEqual(t, visited, []string{"/a"})
+}
@@ -0,0 +1,70 @@
+package ctxmux
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"golang.org/x/net/context"
+)
+
+// ErrShuttingDown is passed to the ErrorHandler for requests that arrive
+// after Shutdown has been called. It implements HTTPError so
+// DefaultErrorHandler renders it as a 503, and a custom ErrorHandler can
+// type-assert for it to detect shutdown specifically.
+var ErrShuttingDown = Error(http.StatusServiceUnavailable, "service unavailable, shutting down", nil)
+
+// MuxBaseContext configures a function used to derive the Mux's root
+// context, following the same signature as http.Server.BaseContext.
+// Assign the Mux's BaseContext method to the Server's BaseContext field
+// to have it invoked automatically:
+//
+//	srv := &http.Server{Handler: mux, BaseContext: mux.BaseContext}
+func MuxBaseContext(f func(net.Listener) context.Context) MuxOption {
+	return func(m *Mux) error {
+		m.baseContextFunc = f
+		return nil
+	}
+}
+
+// BaseContext implements the signature required by http.Server's
+// BaseContext field. The context it returns takes the place of
+// context.Background() as the request context when no MuxContextMaker is
+// configured, and when the MuxContextMaker function returns an error. A
+// context returned by the MuxContextMaker function is used as is and
+// isn't derived from it.
+func (m *Mux) BaseContext(l net.Listener) context.Context {
+	ctx := context.Background()
+	if m.baseContextFunc != nil {
+		ctx = m.baseContextFunc(l)
+	}
+	m.rootContext = ctx
+	return ctx
+}
+
+// requestDone decrements the in-flight request counter, and wakes up
+// every pending Shutdown call once the last in-flight request finishes.
+func (m *Mux) requestDone() {
+	if atomic.AddInt64(&m.inflight, -1) == 0 && atomic.LoadInt32(&m.shuttingDown) != 0 {
+		m.drainedOnce.Do(func() { close(m.drained) })
+	}
+}
+
+// Shutdown marks the Mux as shutting down, so that new requests are
+// refused with ErrShuttingDown via the ErrorHandler, and waits for
+// in-flight requests to finish. It returns when every in-flight request
+// has completed, or when ctx is done, whichever comes first. Shutdown
+// may be called concurrently from multiple goroutines; every caller
+// observes the drain, not just the first.
+func (m *Mux) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&m.shuttingDown, 1)
+	if atomic.LoadInt64(&m.inflight) == 0 {
+		return nil
+	}
+	select {
+	case <-m.drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,108 @@
+package ctxmux_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/daaku/ctxmux"
+	"github.com/facebookgo/ensure"
+	"golang.org/x/net/context"
+)
+
+func TestShutdownWaitsForInFlight(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var actualErr error
+	mux, err := ctxmux.New(
+		ctxmux.MuxErrorHandler(func(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+			actualErr = err
+		}),
+	)
+	ensure.Nil(t, err)
+	mux.GET("/slow", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		hw := httptest.NewRecorder()
+		hr := &http.Request{Method: "GET", URL: &url.URL{Path: "/slow"}}
+		mux.ServeHTTP(hw, hr)
+	}()
+	<-started
+
+	shutdownDone := make(chan error, 1)
+	go func() {
+		shutdownDone <- mux.Shutdown(context.Background())
+	}()
+
+	// a request arriving after Shutdown was called is refused.
+	time.Sleep(10 * time.Millisecond)
+	hw := httptest.NewRecorder()
+	hr := &http.Request{Method: "GET", URL: &url.URL{Path: "/slow"}}
+	mux.ServeHTTP(hw, hr)
+	ensure.DeepEqual(t, actualErr, ctxmux.ErrShuttingDown)
+
+	close(release)
+	wg.Wait()
+	ensure.Nil(t, <-shutdownDone)
+}
+
+func TestShutdownConcurrentCallersAllObserveDrain(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	mux, err := ctxmux.New()
+	ensure.Nil(t, err)
+	mux.GET("/slow", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		hw := httptest.NewRecorder()
+		hr := &http.Request{Method: "GET", URL: &url.URL{Path: "/slow"}}
+		mux.ServeHTTP(hw, hr)
+	}()
+	<-started
+
+	const callers = 2
+	results := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			results <- mux.Shutdown(context.Background())
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i := 0; i < callers; i++ {
+		ensure.Nil(t, <-results)
+	}
+}
+
+func TestShutdownRefusesWith503(t *testing.T) {
+	mux, err := ctxmux.New()
+	ensure.Nil(t, err)
+	mux.GET("/x", func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	ensure.Nil(t, mux.Shutdown(context.Background()))
+
+	hw := httptest.NewRecorder()
+	hr := &http.Request{Method: "GET", URL: &url.URL{Path: "/x"}}
+	mux.ServeHTTP(hw, hr)
+	ensure.DeepEqual(t, hw.Code, http.StatusServiceUnavailable)
+}